@@ -0,0 +1,269 @@
+// Package bus is an in-process publish/subscribe hub for types.Command and
+// types.Event traffic, with a coalescing policy so a driver spamming
+// high-frequency writes (a transition stepping brightness every frame, a
+// sensor burst) can't drown out the occasional turn_on/turn_off in logs or
+// debug UIs.
+package bus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// VerboseKeyer is implemented by a Command or Event whose repeated,
+// high-frequency instances should be coalesced rather than delivered one by
+// one to subscribers that haven't opted into WithVerbose. An empty
+// VerboseKey is treated the same as not implementing the interface at all.
+type VerboseKeyer interface {
+	VerboseKey() string
+}
+
+// EntityIDer is implemented by a message that knows which entity it
+// belongs to, so Recorder can keep replay history per entity.
+type EntityIDer interface {
+	BusEntityID() string
+}
+
+// Envelope is what Subscribe delivers: either a published message, or
+// (when the bus has coalesced a burst of verbose-keyed messages) a Summary
+// describing what was hidden.
+type Envelope struct {
+	Message any
+}
+
+// Summary replaces a burst of coalesced messages sharing a VerboseKey.
+type Summary struct {
+	Key   string
+	Count int
+}
+
+const (
+	coalesceFlushCount    = 1000
+	coalesceFlushInterval = time.Second
+	coalesceScanInterval  = 100 * time.Millisecond
+)
+
+// Filter decides whether a subscriber wants a given Envelope.
+type Filter func(Envelope) bool
+
+type subscription struct {
+	ch      chan Envelope
+	verbose bool
+	filter  Filter
+}
+
+// SubscribeOption configures a Subscribe call.
+type SubscribeOption func(*subscription)
+
+// WithVerbose opts a subscriber into receiving every raw message for a
+// VerboseKey'd command/event instead of periodic Summary envelopes.
+func WithVerbose(verbose bool) SubscribeOption {
+	return func(s *subscription) { s.verbose = verbose }
+}
+
+// Clock abstracts time so an EventBus's coalescing flush can be driven
+// deterministically in tests instead of waiting on real sleeps.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// RealClock is the Clock EventBus uses unless told otherwise.
+var RealClock Clock = realClock{}
+
+// EventBus fans published Commands and Events out to subscribers,
+// coalescing verbose-keyed bursts for subscribers that didn't ask for them
+// raw.
+type EventBus struct {
+	mu       sync.Mutex
+	subs     []*subscription
+	coalesce map[string]*coalesceState
+	recorder *Recorder
+	clock    Clock
+	done     chan struct{}
+}
+
+type coalesceState struct {
+	count int
+	since time.Time
+}
+
+// New creates an EventBus whose Recorder keeps the last recorderSize
+// envelopes per entity (0 disables recording).
+func New(recorderSize int) *EventBus {
+	return NewWithClock(recorderSize, RealClock)
+}
+
+// NewWithClock is New, but driven by clock instead of real time; it exists
+// so tests can exercise the coalescing flush's count- and time-based paths
+// deterministically.
+func NewWithClock(recorderSize int, clock Clock) *EventBus {
+	b := &EventBus{
+		coalesce: map[string]*coalesceState{},
+		recorder: NewRecorder(recorderSize),
+		clock:    clock,
+		done:     make(chan struct{}),
+	}
+	go b.flushLoop()
+	return b
+}
+
+// Recorder returns the bus's Recorder.
+func (b *EventBus) Recorder() *Recorder { return b.recorder }
+
+// Close stops the bus's background coalescing flush. Subscriber channels
+// are left open; callers should stop using the bus after Close.
+func (b *EventBus) Close() { close(b.done) }
+
+// Subscribe returns a channel that receives every published Envelope
+// matching filter (nil matches everything). By default, bursts of
+// verbose-keyed messages arrive as periodic Summary envelopes; pass
+// WithVerbose(true) to receive every raw message instead.
+func (b *EventBus) Subscribe(filter Filter, opts ...SubscribeOption) <-chan Envelope {
+	sub := &subscription{ch: make(chan Envelope, 64), filter: filter}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+	return sub.ch
+}
+
+// Publish sends msg to every matching subscriber, recording it for replay
+// and coalescing it if msg implements VerboseKeyer with a non-empty key.
+func (b *EventBus) Publish(msg any) {
+	env := Envelope{Message: msg}
+	b.recorder.record(msg, env)
+
+	key := verboseKey(msg)
+	if key == "" {
+		b.deliverRaw(env, true)
+		return
+	}
+
+	b.deliverRaw(env, false)
+	b.bumpCoalesce(key)
+}
+
+func verboseKey(msg any) string {
+	keyer, ok := msg.(VerboseKeyer)
+	if !ok {
+		return ""
+	}
+	return keyer.VerboseKey()
+}
+
+// deliverRaw sends env to every verbose subscriber, and additionally to
+// every non-verbose subscriber when toNonVerbose is true.
+func (b *EventBus) deliverRaw(env Envelope, toNonVerbose bool) {
+	b.mu.Lock()
+	subs := append([]*subscription(nil), b.subs...)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		if !sub.verbose && !toNonVerbose {
+			continue
+		}
+		if sub.filter != nil && !sub.filter(env) {
+			continue
+		}
+		select {
+		case sub.ch <- env:
+		default:
+		}
+	}
+}
+
+func (b *EventBus) bumpCoalesce(key string) {
+	b.mu.Lock()
+	st, ok := b.coalesce[key]
+	if !ok {
+		st = &coalesceState{since: b.clock.Now()}
+		b.coalesce[key] = st
+	}
+	st.count++
+	flush := st.count >= coalesceFlushCount
+	var count int
+	if flush {
+		count = st.count
+		st.count = 0
+		st.since = b.clock.Now()
+	}
+	b.mu.Unlock()
+
+	if flush {
+		b.emitSummary(key, count)
+	}
+}
+
+func (b *EventBus) flushLoop() {
+	ticks, stop := b.clock.NewTicker(coalesceScanInterval)
+	defer stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case now := <-ticks:
+			b.flushStale(now)
+		}
+	}
+}
+
+func (b *EventBus) flushStale(now time.Time) {
+	type due struct {
+		key   string
+		count int
+	}
+	var flushed []due
+
+	b.mu.Lock()
+	for key, st := range b.coalesce {
+		if st.count > 0 && now.Sub(st.since) >= coalesceFlushInterval {
+			flushed = append(flushed, due{key: key, count: st.count})
+			st.count = 0
+			st.since = now
+		}
+	}
+	b.mu.Unlock()
+
+	for _, d := range flushed {
+		b.emitSummary(d.key, d.count)
+	}
+}
+
+func (b *EventBus) emitSummary(key string, count int) {
+	env := Envelope{Message: Summary{Key: key, Count: count}}
+	b.mu.Lock()
+	subs := append([]*subscription(nil), b.subs...)
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.verbose {
+			continue
+		}
+		if sub.filter != nil && !sub.filter(env) {
+			continue
+		}
+		select {
+		case sub.ch <- env:
+		default:
+		}
+	}
+}
+
+func summaryString(key string, count int) string {
+	return fmt.Sprintf("%d commands hidden for key %s", count, key)
+}
+
+// String renders a Summary the way a log line or debug UI would show it.
+func (s Summary) String() string { return summaryString(s.Key, s.Count) }
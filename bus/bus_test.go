@@ -0,0 +1,136 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock lets a test drive an EventBus's coalescing flush tick-by-tick
+// instead of waiting on real time.
+type fakeClock struct {
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), ticks: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+func (c *fakeClock) NewTicker(time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {}
+}
+
+// advance moves the clock forward by d and feeds the result to flushLoop's
+// ticker, blocking until flushLoop has consumed it.
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.ticks <- c.now
+}
+
+type verboseMsg struct{ key string }
+
+func (m verboseMsg) VerboseKey() string { return m.key }
+
+func TestPublishCoalescesAtEventCountThreshold(t *testing.T) {
+	clock := newFakeClock()
+	b := NewWithClock(0, clock)
+	defer b.Close()
+
+	sub := b.Subscribe(nil)
+
+	for i := 0; i < coalesceFlushCount; i++ {
+		b.Publish(verboseMsg{key: "light:set_brightness:lamp1"})
+	}
+
+	select {
+	case env := <-sub:
+		summary, ok := env.Message.(Summary)
+		if !ok {
+			t.Fatalf("env.Message = %#v, want Summary", env.Message)
+		}
+		if summary.Count != coalesceFlushCount {
+			t.Fatalf("summary.Count = %d, want %d", summary.Count, coalesceFlushCount)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the count-threshold Summary")
+	}
+
+	select {
+	case env := <-sub:
+		t.Fatalf("got unexpected extra envelope %#v after the coalesced burst", env)
+	default:
+	}
+}
+
+func TestPublishCoalescesAtTimeThreshold(t *testing.T) {
+	clock := newFakeClock()
+	b := NewWithClock(0, clock)
+	defer b.Close()
+
+	sub := b.Subscribe(nil)
+
+	const n = 3
+	for i := 0; i < n; i++ {
+		b.Publish(verboseMsg{key: "light:set_brightness:lamp1"})
+	}
+
+	// Below the count threshold, nothing should have flushed yet.
+	select {
+	case env := <-sub:
+		t.Fatalf("got unexpected early envelope %#v before the flush interval elapsed", env)
+	default:
+	}
+
+	clock.advance(coalesceFlushInterval + 100*time.Millisecond)
+
+	select {
+	case env := <-sub:
+		summary, ok := env.Message.(Summary)
+		if !ok {
+			t.Fatalf("env.Message = %#v, want Summary", env.Message)
+		}
+		if summary.Count != n {
+			t.Fatalf("summary.Count = %d, want %d", summary.Count, n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the time-threshold Summary")
+	}
+}
+
+func TestVerboseSubscriberReceivesRawMessages(t *testing.T) {
+	clock := newFakeClock()
+	b := NewWithClock(0, clock)
+	defer b.Close()
+
+	sub := b.Subscribe(nil, WithVerbose(true))
+	b.Publish(verboseMsg{key: "light:set_brightness:lamp1"})
+
+	select {
+	case env := <-sub:
+		msg, ok := env.Message.(verboseMsg)
+		if !ok || msg.key != "light:set_brightness:lamp1" {
+			t.Fatalf("env.Message = %#v, want the raw verboseMsg", env.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the raw message")
+	}
+}
+
+func TestNonVerboseKeyedMessageDeliveredRaw(t *testing.T) {
+	clock := newFakeClock()
+	b := NewWithClock(0, clock)
+	defer b.Close()
+
+	sub := b.Subscribe(nil)
+	b.Publish(struct{ Type string }{Type: "turn_on"})
+
+	select {
+	case env := <-sub:
+		if env.Message.(struct{ Type string }).Type != "turn_on" {
+			t.Fatalf("env.Message = %#v, want turn_on", env.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the non-keyed message")
+	}
+}
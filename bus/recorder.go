@@ -0,0 +1,48 @@
+package bus
+
+import "sync"
+
+// Recorder keeps the last N Envelopes published for each entity, so a
+// debug UI or test can replay what happened to an entity without having
+// subscribed in time to see it live. Messages that don't implement
+// EntityIDer are kept under the empty-string entity and are still
+// available via Last("").
+type Recorder struct {
+	mu   sync.Mutex
+	size int
+	byID map[string][]Envelope
+}
+
+// NewRecorder creates a Recorder holding up to size envelopes per entity.
+// A non-positive size disables recording.
+func NewRecorder(size int) *Recorder {
+	return &Recorder{size: size, byID: map[string][]Envelope{}}
+}
+
+func (r *Recorder) record(msg any, env Envelope) {
+	if r == nil || r.size <= 0 {
+		return
+	}
+
+	id := ""
+	if idr, ok := msg.(EntityIDer); ok {
+		id = idr.BusEntityID()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	buf := append(r.byID[id], env)
+	if len(buf) > r.size {
+		buf = buf[len(buf)-r.size:]
+	}
+	r.byID[id] = buf
+}
+
+// Last returns a copy of the recorded envelopes for entityID, oldest first.
+func (r *Recorder) Last(entityID string) []Envelope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Envelope, len(r.byID[entityID]))
+	copy(out, r.byID[entityID])
+	return out
+}
@@ -0,0 +1,226 @@
+// Package color represents light color as a single canonical value that can
+// be built from, or converted to, RGB, HSV, CIE 1931 xyY, or Kelvin, with a
+// lossless round-trip through the canonical xyY representation.
+package color
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Value is a point in the CIE 1931 xyY color space: X and Y are the
+// chromaticity coordinates and Brightness is the CIE luminance (0-1,
+// conventionally written as a capital Y alongside the chromaticity pair).
+// Carrying Brightness alongside chromaticity is what makes FromRGB/ToRGB
+// (and the other From*/To* pairs) a lossless round-trip: two colors with the
+// same hue but different magnitudes produce different Values instead of
+// collapsing to the same chromaticity. Callers that separately track
+// brightness (light.State does, via its own Brightness field) are free to
+// ignore this field and drive Value at a fixed brightness instead.
+type Value struct {
+	X, Y       float64
+	Brightness float64
+}
+
+// FromRGB builds a Value from 8-bit sRGB channels, preserving their
+// luminance as Brightness so ToRGB can reproduce the original magnitude.
+func FromRGB(r, g, b int) Value {
+	rf := gammaCorrect(float64(r) / 255)
+	gf := gammaCorrect(float64(g) / 255)
+	bf := gammaCorrect(float64(b) / 255)
+
+	X := rf*0.664511 + gf*0.154324 + bf*0.162028
+	Y := rf*0.283881 + gf*0.668433 + bf*0.047685
+	Z := rf*0.000088 + gf*0.072310 + bf*0.986039
+
+	sum := X + Y + Z
+	if sum == 0 {
+		return Value{}
+	}
+	return Value{X: X / sum, Y: Y / sum, Brightness: clamp01(Y)}
+}
+
+// FromXY builds a Value from CIE 1931 chromaticity coordinates at full
+// brightness. Use FromXYY to also specify the luminance.
+func FromXY(x, y float64) Value {
+	return FromXYY(x, y, 1.0)
+}
+
+// FromXYY builds a Value directly from CIE 1931 chromaticity coordinates
+// and a luminance (0-1).
+func FromXYY(x, y, brightness float64) Value {
+	return Value{X: x, Y: y, Brightness: clamp01(brightness)}
+}
+
+// FromHSV builds a Value from hue (0-360), saturation and value (each
+// 0-1) by way of FromRGB, so Brightness is the luminance of the resulting
+// RGB color rather than v itself.
+func FromHSV(h, s, v float64) Value {
+	r, g, b := hsvToRGB(h, s, v)
+	return FromRGB(r, g, b)
+}
+
+// FromKelvin builds a Value from a color temperature in Kelvin using the
+// Planckian locus approximation valid over roughly 1667K-25000K, at full
+// brightness.
+func FromKelvin(kelvin int) Value {
+	t := float64(kelvin)
+	var x float64
+	switch {
+	case t <= 4000:
+		x = -0.2661239e9/cube(t) - 0.2343589e6/sq(t) + 0.8776956e3/t + 0.179910
+	default:
+		x = -3.0258469e9/cube(t) + 2.1070379e6/sq(t) + 0.2226347e3/t + 0.240390
+	}
+
+	var y float64
+	switch {
+	case t <= 2222:
+		y = -1.1063814*cube(x) - 1.34811020*sq(x) + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*cube(x) - 1.37418593*sq(x) + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*cube(x) - 5.87338670*sq(x) + 3.75112997*x - 0.37001483
+	}
+	return Value{X: x, Y: y, Brightness: 1.0}
+}
+
+// ToRGB returns the 8-bit sRGB channels for v at its own Brightness,
+// clamping any channel the gamut can't reproduce at that brightness rather
+// than rescaling the whole color back up to full brightness.
+func (v Value) ToRGB() (r, g, b int) {
+	z := 1 - v.X - v.Y
+	if v.Y == 0 {
+		return 0, 0, 0
+	}
+	Y := v.Brightness
+	X := (Y / v.Y) * v.X
+	Z := (Y / v.Y) * z
+
+	rf := X*1.656492 - Y*0.354851 - Z*0.255038
+	gf := -X*0.707196 + Y*1.655397 + Z*0.036152
+	bf := X*0.051713 - Y*0.121364 + Z*1.011530
+
+	rf, gf, bf = reverseGamma(rf), reverseGamma(gf), reverseGamma(bf)
+	return clamp255(rf), clamp255(gf), clamp255(bf)
+}
+
+// ToXY returns the canonical CIE 1931 chromaticity coordinates.
+func (v Value) ToXY() (x, y float64) { return v.X, v.Y }
+
+// ToKelvin estimates the correlated color temperature for v using McCamy's
+// approximation. The result is only meaningful for chromaticities reasonably
+// close to the Planckian locus (i.e. values that came from, or are near,
+// FromKelvin).
+func (v Value) ToKelvin() int {
+	n := (v.X - 0.3320) / (0.1858 - v.Y)
+	cct := 437*cube(n) + 3601*sq(n) + 6861*n + 5517
+	return int(math.Round(cct))
+}
+
+// MarshalJSON always serializes the canonical xyY representation, so
+// decoding the result always round-trips Brightness losslessly regardless
+// of which From* constructor built v.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		XY         [2]float64 `json:"xy"`
+		Brightness float64    `json:"brightness"`
+	}{XY: [2]float64{v.X, v.Y}, Brightness: v.Brightness})
+}
+
+// UnmarshalJSON accepts any of {"rgb":[r,g,b]}, {"xy":[x,y],"brightness":b},
+// {"hsv":[h,s,v]}, or {"kelvin":2700}. The "brightness" field is optional
+// alongside "xy" and defaults to full brightness when omitted, for
+// compatibility with callers that only ever dealt in chromaticity.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	var shape struct {
+		RGB        *[3]int     `json:"rgb"`
+		XY         *[2]float64 `json:"xy"`
+		Brightness *float64    `json:"brightness"`
+		HSV        *[3]float64 `json:"hsv"`
+		Kelvin     *int        `json:"kelvin"`
+	}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		return err
+	}
+
+	switch {
+	case shape.RGB != nil:
+		*v = FromRGB(shape.RGB[0], shape.RGB[1], shape.RGB[2])
+	case shape.XY != nil:
+		brightness := 1.0
+		if shape.Brightness != nil {
+			brightness = *shape.Brightness
+		}
+		*v = FromXYY(shape.XY[0], shape.XY[1], brightness)
+	case shape.HSV != nil:
+		*v = FromHSV(shape.HSV[0], shape.HSV[1], shape.HSV[2])
+	case shape.Kelvin != nil:
+		*v = FromKelvin(*shape.Kelvin)
+	default:
+		return fmt.Errorf("color: value must set one of rgb, xy, hsv, or kelvin")
+	}
+	return nil
+}
+
+func gammaCorrect(c float64) float64 {
+	if c > 0.04045 {
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return c / 12.92
+}
+
+func reverseGamma(c float64) float64 {
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+func clamp255(c float64) int {
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 255
+	}
+	return int(math.Round(c * 255))
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func hsvToRGB(h, s, v float64) (r, g, b int) {
+	c := v * s
+	hp := math.Mod(h, 360) / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	m := v - c
+
+	var rf, gf, bf float64
+	switch {
+	case hp < 1:
+		rf, gf, bf = c, x, 0
+	case hp < 2:
+		rf, gf, bf = x, c, 0
+	case hp < 3:
+		rf, gf, bf = 0, c, x
+	case hp < 4:
+		rf, gf, bf = 0, x, c
+	case hp < 5:
+		rf, gf, bf = x, 0, c
+	default:
+		rf, gf, bf = c, 0, x
+	}
+	return clamp255(rf + m), clamp255(gf + m), clamp255(bf + m)
+}
+
+func sq(v float64) float64   { return v * v }
+func cube(v float64) float64 { return v * v * v }
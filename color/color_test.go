@@ -0,0 +1,104 @@
+package color
+
+import "testing"
+
+func TestFromRGBRoundTrip(t *testing.T) {
+	cases := []struct {
+		name    string
+		r, g, b int
+	}{
+		{"red", 255, 0, 0},
+		{"dim red", 80, 0, 0},
+		{"white", 255, 255, 255},
+		{"dim white", 40, 40, 40},
+		{"teal", 0, 128, 128},
+		{"black", 0, 0, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := FromRGB(tc.r, tc.g, tc.b)
+			r, g, b := v.ToRGB()
+			if abs(r-tc.r) > 1 || abs(g-tc.g) > 1 || abs(b-tc.b) > 1 {
+				t.Fatalf("FromRGB(%d,%d,%d).ToRGB() = (%d,%d,%d), want within rounding of original", tc.r, tc.g, tc.b, r, g, b)
+			}
+		})
+	}
+}
+
+func TestFromRGBPreservesMagnitude(t *testing.T) {
+	// Two reds at different brightness must not collapse to the same Value:
+	// that was the bug behind the "lossless round-trip" claim not holding.
+	bright := FromRGB(200, 0, 0)
+	dim := FromRGB(80, 0, 0)
+
+	if bright.Brightness == dim.Brightness {
+		t.Fatalf("FromRGB(200,0,0).Brightness == FromRGB(80,0,0).Brightness (%v); want distinct brightness for distinct magnitudes", bright.Brightness)
+	}
+
+	br, _, _ := bright.ToRGB()
+	dr, _, _ := dim.ToRGB()
+	if br == dr {
+		t.Fatalf("both ToRGB() returned r=%d; dim red should round-trip dimmer than bright red", br)
+	}
+}
+
+func TestFromXYDefaultsToFullBrightness(t *testing.T) {
+	v := FromXY(0.64, 0.33)
+	if v.Brightness != 1.0 {
+		t.Fatalf("FromXY Brightness = %v, want 1.0", v.Brightness)
+	}
+}
+
+func TestFromXYYRoundTrip(t *testing.T) {
+	v := FromXYY(0.64, 0.33, 0.25)
+	if v.Brightness != 0.25 {
+		t.Fatalf("FromXYY Brightness = %v, want 0.25", v.Brightness)
+	}
+	x, y := v.ToXY()
+	if x != 0.64 || y != 0.33 {
+		t.Fatalf("ToXY() = (%v,%v), want (0.64,0.33)", x, y)
+	}
+}
+
+func TestValueJSONRoundTrip(t *testing.T) {
+	v := FromRGB(80, 0, 0)
+
+	data, err := v.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+
+	var decoded Value
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+
+	if decoded.X != v.X || decoded.Y != v.Y || decoded.Brightness != v.Brightness {
+		t.Fatalf("decoded %+v, want %+v", decoded, v)
+	}
+}
+
+func TestValueUnmarshalXYWithoutBrightness(t *testing.T) {
+	var v Value
+	if err := v.UnmarshalJSON([]byte(`{"xy":[0.4,0.4]}`)); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if v.Brightness != 1.0 {
+		t.Fatalf("Brightness = %v, want 1.0 for xy with no explicit brightness", v.Brightness)
+	}
+}
+
+func TestValueUnmarshalRejectsEmptyShape(t *testing.T) {
+	var v Value
+	if err := v.UnmarshalJSON([]byte(`{}`)); err == nil {
+		t.Fatal("expected error for a value with none of rgb/xy/hsv/kelvin set")
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package color
+
+// Gamut is the triangle of reproducible chromaticities for a product model,
+// following the naming Hue-style fixtures use for their three historical
+// gamuts.
+type Gamut struct {
+	Red, Green, Blue Value
+}
+
+const (
+	GamutA = "gamut_a" // early bulbs (e.g. LivingColors Gen1)
+	GamutB = "gamut_b" // LivingColors Iris, Bloom, Aura, LightStrips
+	GamutC = "gamut_c" // current-generation bulbs
+)
+
+var gamuts = map[string]Gamut{
+	GamutA: {
+		Red:   Value{X: 0.704, Y: 0.296},
+		Green: Value{X: 0.2151, Y: 0.7106},
+		Blue:  Value{X: 0.138, Y: 0.08},
+	},
+	GamutB: {
+		Red:   Value{X: 0.675, Y: 0.322},
+		Green: Value{X: 0.409, Y: 0.518},
+		Blue:  Value{X: 0.167, Y: 0.04},
+	},
+	GamutC: {
+		Red:   Value{X: 0.6915, Y: 0.3083},
+		Green: Value{X: 0.17, Y: 0.7},
+		Blue:  Value{X: 0.1532, Y: 0.0475},
+	},
+}
+
+// ClampToGamut constrains v to the reproducible triangle for model, moving
+// it to the nearest point on the triangle's edge when it falls outside.
+// Unknown models are returned unchanged, since a driver with no declared
+// gamut can't usefully be second-guessed.
+func ClampToGamut(v Value, model string) Value {
+	g, ok := gamuts[model]
+	if !ok {
+		return v
+	}
+	if g.contains(v) {
+		return v
+	}
+	return g.closestPoint(v)
+}
+
+func (g Gamut) contains(p Value) bool {
+	sign := func(a, b, c Value) float64 {
+		return (a.X-c.X)*(b.Y-c.Y) - (b.X-c.X)*(a.Y-c.Y)
+	}
+	d1 := sign(p, g.Red, g.Green)
+	d2 := sign(p, g.Green, g.Blue)
+	d3 := sign(p, g.Blue, g.Red)
+
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+func (g Gamut) closestPoint(p Value) Value {
+	best := closestOnSegment(g.Red, g.Green, p)
+	for _, candidate := range []Value{
+		closestOnSegment(g.Green, g.Blue, p),
+		closestOnSegment(g.Blue, g.Red, p),
+	} {
+		if distance(candidate, p) < distance(best, p) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// closestOnSegment projects p onto the chromaticity segment a-b, keeping
+// p's own Brightness: moving a color to the edge of a gamut shouldn't change
+// how bright it was asked to be.
+func closestOnSegment(a, b, p Value) Value {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return Value{X: a.X, Y: a.Y, Brightness: p.Brightness}
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lenSq
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	return Value{X: a.X + t*dx, Y: a.Y + t*dy, Brightness: p.Brightness}
+}
+
+func distance(a, b Value) float64 {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,289 @@
+package light
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/slidebolt/sdk-entities/color"
+)
+
+const (
+	ActionSetTransition = "set_transition"
+	ActionFlash         = "flash"
+	ActionColorloop     = "colorloop"
+)
+
+// Curve is the interpolation shape a transition follows between its start
+// and target state.
+type Curve string
+
+const (
+	CurveLinear  Curve = "linear"
+	CurveEaseIn  Curve = "ease_in"
+	CurveEaseOut Curve = "ease_out"
+)
+
+// TransitionParams describes a smooth move to target over duration_ms.
+type TransitionParams struct {
+	Target     State `json:"target"`
+	DurationMS int   `json:"duration_ms"`
+	Curve      Curve `json:"curve,omitempty"`
+}
+
+// FlashParams describes an on/off flash sequence: count repetitions of
+// on_ms spent on followed by off_ms spent off.
+type FlashParams struct {
+	Count int `json:"count"`
+	OnMS  int `json:"on_ms"`
+	OffMS int `json:"off_ms"`
+}
+
+// ColorloopParams describes a continuous hue sweep at speed degrees/sec,
+// optionally bounded to [hue_range[0], hue_range[1]] instead of the full
+// 360 degrees.
+type ColorloopParams struct {
+	Speed    float64    `json:"speed"`
+	HueRange [2]float64 `json:"hue_range,omitempty"`
+}
+
+// Effect is a realizable, time-bounded light transformation. Step advances
+// the effect by the time elapsed since it started and returns the state to
+// show at that point, and whether the effect has finished.
+type Effect interface {
+	Step(elapsed time.Duration) (state State, done bool)
+}
+
+// NewTransitionEffect builds an Effect that moves from from to params.Target
+// over params.DurationMS, following params.Curve (CurveLinear if empty).
+func NewTransitionEffect(from State, params TransitionParams) Effect {
+	return &transitionEffect{
+		from:     from,
+		to:       params.Target,
+		duration: time.Duration(params.DurationMS) * time.Millisecond,
+		curve:    params.Curve,
+	}
+}
+
+type transitionEffect struct {
+	from, to State
+	duration time.Duration
+	curve    Curve
+}
+
+func (t *transitionEffect) Step(elapsed time.Duration) (State, bool) {
+	if t.duration <= 0 || elapsed >= t.duration {
+		return t.to, true
+	}
+
+	progress := applyCurve(float64(elapsed)/float64(t.duration), t.curve)
+	st := t.from
+	st.Power = t.to.Power
+	st.Brightness = lerpInt(t.from.Brightness, t.to.Brightness, progress)
+
+	if t.to.Color != nil {
+		from := t.from.Color
+		if from == nil {
+			from = t.to.Color
+		}
+		fx, fy := from.ToXY()
+		tx, ty := t.to.Color.ToXY()
+		brightness := lerp(from.Brightness, t.to.Color.Brightness, progress)
+		v := color.FromXYY(lerp(fx, tx, progress), lerp(fy, ty, progress), brightness)
+		st.Color = &v
+	}
+	return st, false
+}
+
+func applyCurve(progress float64, curve Curve) float64 {
+	switch curve {
+	case CurveEaseIn:
+		return progress * progress
+	case CurveEaseOut:
+		return progress * (2 - progress)
+	default:
+		return progress
+	}
+}
+
+func lerp(from, to, progress float64) float64 { return from + (to-from)*progress }
+func lerpInt(from, to int, progress float64) int {
+	return from + int(math.Round(float64(to-from)*progress))
+}
+
+// NewFlashEffect builds an Effect that flashes power on/off for
+// params.Count cycles, then settles back on from.
+func NewFlashEffect(from State, params FlashParams) Effect {
+	return &flashEffect{from: from, params: params}
+}
+
+type flashEffect struct {
+	from   State
+	params FlashParams
+}
+
+func (f *flashEffect) Step(elapsed time.Duration) (State, bool) {
+	cycle := time.Duration(f.params.OnMS+f.params.OffMS) * time.Millisecond
+	total := cycle * time.Duration(f.params.Count)
+	if cycle <= 0 || elapsed >= total {
+		return f.from, true
+	}
+
+	st := f.from
+	st.Power = elapsed%cycle < time.Duration(f.params.OnMS)*time.Millisecond
+	return st, false
+}
+
+// NewColorloopEffect builds an Effect that continuously sweeps hue at
+// params.Speed degrees/sec within params.HueRange (the full 0-360 range
+// when HueRange is left zero-valued). It never reports done; callers stop it
+// via Runner.Cancel.
+func NewColorloopEffect(from State, params ColorloopParams) Effect {
+	lo, hi := params.HueRange[0], params.HueRange[1]
+	if hi <= lo {
+		lo, hi = 0, 360
+	}
+	return &colorloopEffect{from: from, speed: params.Speed, lo: lo, hi: hi}
+}
+
+type colorloopEffect struct {
+	from   State
+	speed  float64
+	lo, hi float64
+}
+
+func (c *colorloopEffect) Step(elapsed time.Duration) (State, bool) {
+	span := c.hi - c.lo
+	hue := c.lo + math.Mod(c.speed*elapsed.Seconds(), span)
+	v := color.FromHSV(hue, 1, 1)
+	st := c.from
+	st.Power = true
+	st.Color = &v
+	return st, false
+}
+
+// Clock abstracts time so a Runner can be driven deterministically in tests
+// instead of waiting on real sleeps.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// RealClock is the Clock Runner uses unless told otherwise.
+var RealClock Clock = realClock{}
+
+// Runner realizes Effects that a driver can't perform natively: it steps an
+// Effect on a ticker, writes each interpolated State into the entity's
+// Effective, and emits the Command a driver would need to send to reach
+// that interpolated state. Desired is left untouched throughout — Run does
+// not call SetDesiredFromCommand — so it is safe to run concurrently with
+// callers that do.
+type Runner struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func NewRunner() *Runner {
+	return &Runner{cancels: map[string]context.CancelFunc{}}
+}
+
+// Run starts effect for entityID against store, stepping every interval on
+// clock until the effect reports done or Cancel(entityID) is called. Run
+// replaces any effect already running for the same entity. The returned
+// channel carries one Command per step and is closed when the effect ends.
+func (r *Runner) Run(entityID string, store Store, effect Effect, clock Clock, interval time.Duration) <-chan Command {
+	r.Cancel(entityID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.cancels[entityID] = cancel
+	r.mu.Unlock()
+
+	out := make(chan Command)
+	go r.run(ctx, entityID, store, effect, clock, interval, out)
+	return out
+}
+
+func (r *Runner) run(ctx context.Context, entityID string, store Store, effect Effect, clock Clock, interval time.Duration, out chan<- Command) {
+	defer close(out)
+
+	ticks, stop := clock.NewTicker(interval)
+	defer stop()
+
+	start := clock.Now()
+	var prev State
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticks:
+			st, done := effect.Step(now.Sub(start))
+			_ = store.SetEffective(st)
+			for _, cmd := range diffCommands(prev, st) {
+				cmd.EntityID = entityID
+				select {
+				case out <- cmd:
+				case <-ctx.Done():
+					return
+				}
+			}
+			prev = st
+			if done {
+				r.mu.Lock()
+				// A cancelled ctx means Run or Cancel already removed this
+				// effect's entry and may have registered a replacement.
+				if ctx.Err() == nil {
+					delete(r.cancels, entityID)
+				}
+				r.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// Cancel stops any effect running for entityID. It is safe to call
+// concurrently with Store.SetDesiredFromCommand and with Run itself, since
+// an effect only ever writes Effective.
+func (r *Runner) Cancel(entityID string) {
+	r.mu.Lock()
+	cancel, ok := r.cancels[entityID]
+	if ok {
+		delete(r.cancels, entityID)
+	}
+	r.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// diffCommands returns the minimal Commands needed to move a driver from
+// prev to next.
+func diffCommands(prev, next State) []Command {
+	var cmds []Command
+	if prev.Power != next.Power {
+		action := ActionTurnOff
+		if next.Power {
+			action = ActionTurnOn
+		}
+		cmds = append(cmds, Command{Type: action})
+	}
+	if prev.Brightness != next.Brightness {
+		b := next.Brightness
+		cmds = append(cmds, Command{Type: ActionSetBrightness, Brightness: &b})
+	}
+	if next.Color != nil && (prev.Color == nil || *prev.Color != *next.Color) {
+		c := *next.Color
+		cmds = append(cmds, Command{Type: ActionSetColor, Color: &c})
+	}
+	return cmds
+}
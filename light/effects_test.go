@@ -0,0 +1,106 @@
+package light
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slidebolt/sdk-types"
+)
+
+// fakeClock lets a test drive a Runner tick-by-tick instead of waiting on
+// real time.
+type fakeClock struct {
+	start time.Time
+	ticks chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{start: time.Unix(0, 0), ticks: make(chan time.Time)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.start }
+func (c *fakeClock) NewTicker(time.Duration) (<-chan time.Time, func()) {
+	return c.ticks, func() {}
+}
+
+func (c *fakeClock) tick(elapsed time.Duration) {
+	c.ticks <- c.start.Add(elapsed)
+}
+
+func TestRunnerStepsTransitionAndClosesOnDone(t *testing.T) {
+	entity := &types.Entity{ID: "lamp1", Domain: Type}
+	store := Bind(entity)
+	clock := newFakeClock()
+
+	effect := NewTransitionEffect(State{Power: false, Brightness: 0}, TransitionParams{
+		Target:     State{Power: true, Brightness: 100},
+		DurationMS: 100,
+	})
+
+	runner := NewRunner()
+	out := runner.Run(entity.ID, store, effect, clock, time.Millisecond)
+
+	clock.tick(50 * time.Millisecond)
+	var cmds []Command
+	cmds = append(cmds, <-out, <-out)
+
+	clock.tick(100 * time.Millisecond)
+	cmds = append(cmds, <-out)
+
+	if _, ok := <-out; ok {
+		t.Fatal("expected out to be closed once the effect reports done")
+	}
+
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, want 3 (turn_on, set_brightness halfway, set_brightness at done)", len(cmds))
+	}
+	if cmds[0].Type != ActionTurnOn {
+		t.Fatalf("cmds[0].Type = %q, want %q", cmds[0].Type, ActionTurnOn)
+	}
+	if cmds[len(cmds)-1].Brightness == nil || *cmds[len(cmds)-1].Brightness != 100 {
+		t.Fatalf("final brightness command = %+v, want 100", cmds[len(cmds)-1])
+	}
+
+	effective, err := decodeState(entity.Data.Effective)
+	if err != nil {
+		t.Fatalf("decodeState: %v", err)
+	}
+	if !effective.Power || effective.Brightness != 100 {
+		t.Fatalf("Effective = %+v, want Power=true Brightness=100", effective)
+	}
+
+	for _, cmd := range cmds {
+		if cmd.EntityID != entity.ID {
+			t.Fatalf("cmd %+v EntityID = %q, want %q", cmd, cmd.EntityID, entity.ID)
+		}
+	}
+	if key := cmds[1].VerboseKey(); key == "" {
+		t.Fatal("VerboseKey() is empty for a stamped set_brightness command, so bus coalescing can never key it")
+	}
+}
+
+func TestRunnerCancelStopsEffect(t *testing.T) {
+	entity := &types.Entity{ID: "lamp1", Domain: Type}
+	store := Bind(entity)
+	clock := newFakeClock()
+
+	effect := NewColorloopEffect(State{Power: false}, ColorloopParams{Speed: 10})
+	runner := NewRunner()
+	out := runner.Run(entity.ID, store, effect, clock, time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(closed)
+	}()
+
+	clock.tick(10 * time.Millisecond)
+	runner.Cancel(entity.ID)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for out to close after Cancel")
+	}
+}
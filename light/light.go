@@ -4,44 +4,140 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/slidebolt/sdk-entities/color"
 	"github.com/slidebolt/sdk-types"
 )
 
 const Type = "light"
 
 const (
-	ActionTurnOn         = "turn_on"
-	ActionTurnOff        = "turn_off"
-	ActionSetBrightness  = "set_brightness"
+	ActionTurnOn        = "turn_on"
+	ActionTurnOff       = "turn_off"
+	ActionSetBrightness = "set_brightness"
+	ActionSetColor      = "set_color"
+	ActionSetScene      = "set_scene"
+
+	// ActionSetRGB and ActionSetTemperature are kept for backward
+	// compatibility with existing drivers: both still parse, populating the
+	// same Color field as ActionSetColor. New callers should prefer
+	// ActionSetColor.
 	ActionSetRGB         = "set_rgb"
 	ActionSetTemperature = "set_temperature"
-	ActionSetScene       = "set_scene"
 )
 
 type State struct {
-	Power       bool   `json:"power"`
-	Brightness  int    `json:"brightness,omitempty"`
-	RGB         []int  `json:"rgb,omitempty"`
-	Temperature int    `json:"temperature,omitempty"`
-	Scene       string `json:"scene,omitempty"`
+	Power      bool         `json:"power"`
+	Brightness int          `json:"brightness,omitempty"`
+	Color      *color.Value `json:"color,omitempty"`
+	Scene      string       `json:"scene,omitempty"`
 }
 
 type Command struct {
-	Type        string  `json:"type"`
-	Brightness  *int    `json:"brightness,omitempty"`
-	RGB         *[]int  `json:"rgb,omitempty"`
-	Temperature *int    `json:"temperature,omitempty"`
-	Scene       *string `json:"scene,omitempty"`
+	Type       string            `json:"type"`
+	Brightness *int              `json:"brightness,omitempty"`
+	Color      *color.Value      `json:"color,omitempty"`
+	Scene      *string           `json:"scene,omitempty"`
+	Transition *TransitionParams `json:"transition,omitempty"`
+	Flash      *FlashParams      `json:"flash,omitempty"`
+	Colorloop  *ColorloopParams  `json:"colorloop,omitempty"`
+
+	// EntityID is not part of the wire payload; callers that publish a
+	// Command onto an event bus set it first so bus.VerboseKeyer and
+	// bus.EntityIDer can key/group per entity.
+	EntityID string `json:"-"`
+}
+
+// VerboseKey implements bus.VerboseKeyer: high-frequency state writes
+// (brightness/color changes, including during a transition) are coalesced
+// so they don't drown out turn_on/turn_off/set_scene in logs or debug UIs.
+func (c Command) VerboseKey() string {
+	if c.EntityID == "" {
+		return ""
+	}
+	switch c.Type {
+	case ActionSetBrightness, ActionSetColor, ActionSetRGB, ActionSetTemperature:
+		return fmt.Sprintf("light:%s:%s", c.Type, c.EntityID)
+	default:
+		return ""
+	}
+}
+
+// BusEntityID implements bus.EntityIDer.
+func (c Command) BusEntityID() string { return c.EntityID }
+
+// UnmarshalJSON decodes the canonical {"color": ...} shape plus, for
+// backward compatibility, the legacy {"rgb": [r,g,b]} and {"temperature": n}
+// shapes used before the color package existed. A legacy field is only
+// consulted when "color" is absent.
+func (c *Command) UnmarshalJSON(data []byte) error {
+	type alias Command
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*c = Command(a)
+	return c.applyLegacyColor(data)
+}
+
+func (c *Command) applyLegacyColor(data []byte) error {
+	if c.Color != nil {
+		return nil
+	}
+	var legacy struct {
+		RGB         *[3]int `json:"rgb"`
+		Temperature *int    `json:"temperature"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	switch {
+	case legacy.RGB != nil:
+		v := color.FromRGB(legacy.RGB[0], legacy.RGB[1], legacy.RGB[2])
+		c.Color = &v
+	case legacy.Temperature != nil:
+		v := color.FromKelvin(*legacy.Temperature)
+		c.Color = &v
+	}
+	return nil
 }
 
 type Event struct {
-	Type             string   `json:"type"`
-	Brightness       *int     `json:"brightness,omitempty"`
-	RGB              *[]int   `json:"rgb,omitempty"`
-	Temperature      *int     `json:"temperature,omitempty"`
-	Scene            *string  `json:"scene,omitempty"`
-	AvailableActions []string `json:"available_actions,omitempty"`
-	Cause            string   `json:"cause,omitempty"`
+	Type             string       `json:"type"`
+	Brightness       *int         `json:"brightness,omitempty"`
+	Color            *color.Value `json:"color,omitempty"`
+	Scene            *string      `json:"scene,omitempty"`
+	AvailableActions []string     `json:"available_actions,omitempty"`
+	Cause            string       `json:"cause,omitempty"`
+}
+
+// UnmarshalJSON mirrors Command's legacy rgb/temperature compatibility shim.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type alias Event
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*e = Event(a)
+
+	if e.Color != nil {
+		return nil
+	}
+	var legacy struct {
+		RGB         *[3]int `json:"rgb"`
+		Temperature *int    `json:"temperature"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	switch {
+	case legacy.RGB != nil:
+		v := color.FromRGB(legacy.RGB[0], legacy.RGB[1], legacy.RGB[2])
+		e.Color = &v
+	case legacy.Temperature != nil:
+		v := color.FromKelvin(*legacy.Temperature)
+		e.Color = &v
+	}
+	return nil
 }
 
 func init() {
@@ -50,22 +146,40 @@ func init() {
 
 func Describe() types.DomainDescriptor {
 	brightness := []types.FieldDescriptor{{Name: "brightness", Type: "int", Required: true, Min: intPtr(0), Max: intPtr(100)}}
-	rgb := []types.FieldDescriptor{{Name: "rgb", Type: "[]int", Required: true}}
-	temperature := []types.FieldDescriptor{{Name: "temperature", Type: "int", Required: true}}
+	colorField := []types.FieldDescriptor{{Name: "color", Type: "color.Value", Required: true}}
 	scene := []types.FieldDescriptor{{Name: "scene", Type: "string", Required: true}}
 
 	actions := []types.ActionDescriptor{
 		{Action: ActionTurnOn},
 		{Action: ActionTurnOff},
 		{Action: ActionSetBrightness, Fields: brightness},
-		{Action: ActionSetRGB, Fields: rgb},
-		{Action: ActionSetTemperature, Fields: temperature},
+		{Action: ActionSetColor, Fields: colorField},
 		{Action: ActionSetScene, Fields: scene},
 	}
 
+	transition := []types.FieldDescriptor{
+		{Name: "transition.target", Type: "light.State", Required: true},
+		{Name: "transition.duration_ms", Type: "int", Required: true, Min: intPtr(1)},
+	}
+	flash := []types.FieldDescriptor{
+		{Name: "flash.count", Type: "int", Required: true, Min: intPtr(1)},
+		{Name: "flash.on_ms", Type: "int", Required: true, Min: intPtr(0)},
+		{Name: "flash.off_ms", Type: "int", Required: true, Min: intPtr(0)},
+	}
+	colorloop := []types.FieldDescriptor{{Name: "colorloop.speed", Type: "float64", Required: true}}
+
+	// Effects are commands only: a driver never reports "flash" or
+	// "colorloop" back as something that happened to it, so they don't
+	// belong in Events.
+	commands := append(append([]types.ActionDescriptor{}, actions...),
+		types.ActionDescriptor{Action: ActionSetTransition, Fields: transition},
+		types.ActionDescriptor{Action: ActionFlash, Fields: flash},
+		types.ActionDescriptor{Action: ActionColorloop, Fields: colorloop},
+	)
+
 	return types.DomainDescriptor{
 		Domain:   Type,
-		Commands: actions,
+		Commands: commands,
 		Events:   actions,
 	}
 }
@@ -77,9 +191,11 @@ func SupportedActions() []string {
 		ActionTurnOn,
 		ActionTurnOff,
 		ActionSetBrightness,
-		ActionSetRGB,
-		ActionSetTemperature,
+		ActionSetColor,
 		ActionSetScene,
+		ActionSetTransition,
+		ActionFlash,
+		ActionColorloop,
 	}
 }
 
@@ -108,14 +224,9 @@ func ValidateCommand(c Command) error {
 			return fmt.Errorf("brightness required for %s", ActionSetBrightness)
 		}
 		return nil
-	case ActionSetRGB:
-		if c.RGB == nil || len(*c.RGB) != 3 {
-			return fmt.Errorf("rgb[3] required for %s", ActionSetRGB)
-		}
-		return nil
-	case ActionSetTemperature:
-		if c.Temperature == nil {
-			return fmt.Errorf("temperature required for %s", ActionSetTemperature)
+	case ActionSetColor, ActionSetRGB, ActionSetTemperature:
+		if c.Color == nil {
+			return fmt.Errorf("color required for %s", c.Type)
 		}
 		return nil
 	case ActionSetScene:
@@ -123,6 +234,24 @@ func ValidateCommand(c Command) error {
 			return fmt.Errorf("scene required for %s", ActionSetScene)
 		}
 		return nil
+	case ActionSetTransition:
+		if c.Transition == nil {
+			return fmt.Errorf("transition required for %s", ActionSetTransition)
+		}
+		if c.Transition.DurationMS <= 0 {
+			return fmt.Errorf("transition.duration_ms must be > 0 for %s", ActionSetTransition)
+		}
+		return nil
+	case ActionFlash:
+		if c.Flash == nil || c.Flash.Count <= 0 {
+			return fmt.Errorf("flash.count must be > 0 for %s", ActionFlash)
+		}
+		return nil
+	case ActionColorloop:
+		if c.Colorloop == nil || c.Colorloop.Speed <= 0 {
+			return fmt.Errorf("colorloop.speed must be > 0 for %s", ActionColorloop)
+		}
+		return nil
 	default:
 		return fmt.Errorf("unsupported light command: %s", c.Type)
 	}
@@ -130,7 +259,7 @@ func ValidateCommand(c Command) error {
 
 func ValidateEvent(e Event) error {
 	switch e.Type {
-	case ActionTurnOn, ActionTurnOff, ActionSetBrightness, ActionSetRGB, ActionSetTemperature, ActionSetScene:
+	case ActionTurnOn, ActionTurnOff, ActionSetBrightness, ActionSetColor, ActionSetRGB, ActionSetTemperature, ActionSetScene:
 		return nil
 	default:
 		return fmt.Errorf("unsupported light event: %s", e.Type)
@@ -140,12 +269,23 @@ func ValidateEvent(e Event) error {
 // Store binds to an Entity and manages desired/reported/effective light state.
 type Store struct {
 	entity *types.Entity
+	gamut  string
 }
 
 func Bind(entity *types.Entity) Store {
 	return Store{entity: entity}
 }
 
+// WithGamut returns a copy of s that clamps any Color written through
+// SetDesiredFromCommand to the named product gamut (see color.ClampToGamut)
+// before it reaches Desired. Drivers for fixtures with a known, narrower
+// gamut than the full CIE 1931 space should bind through this so Desired
+// never asks for a chromaticity the hardware can't reproduce.
+func (s Store) WithGamut(model string) Store {
+	s.gamut = model
+	return s
+}
+
 func (s Store) EnsureDefaultActions() {
 	if len(s.entity.Actions) == 0 {
 		s.entity.Actions = SupportedActions()
@@ -173,16 +313,26 @@ func (s Store) SetDesiredFromCommand(cmd Command) error {
 		st.Power = false
 	case ActionSetBrightness:
 		st.Brightness = *cmd.Brightness
-	case ActionSetRGB:
-		st.RGB = cloneInts(*cmd.RGB)
-	case ActionSetTemperature:
-		st.Temperature = *cmd.Temperature
+	case ActionSetColor, ActionSetRGB, ActionSetTemperature:
+		st.Color = s.clampToGamut(cmd.Color)
 	case ActionSetScene:
 		st.Scene = *cmd.Scene
+	case ActionSetTransition:
+		st.Power = cmd.Transition.Target.Power
+		st.Brightness = cmd.Transition.Target.Brightness
+		st.Color = s.clampToGamut(cmd.Transition.Target.Color)
+	case ActionFlash, ActionColorloop:
+		// Effects-only commands: they drive Effective via a Runner and leave
+		// the resting Desired state as-is.
 	}
 	return s.writeDesired(st)
 }
 
+// SetEffective overwrites the entity's Effective state directly. It exists
+// for Runner, which interpolates between Desired transitions and needs to
+// publish each intermediate frame without disturbing Desired or Reported.
+func (s Store) SetEffective(st State) error { return s.writeEffective(st) }
+
 func (s Store) SetReportedFromEvent(evt Event) error {
 	st, _ := s.Reported()
 	switch evt.Type {
@@ -194,13 +344,9 @@ func (s Store) SetReportedFromEvent(evt Event) error {
 		if evt.Brightness != nil {
 			st.Brightness = *evt.Brightness
 		}
-	case ActionSetRGB:
-		if evt.RGB != nil {
-			st.RGB = cloneInts(*evt.RGB)
-		}
-	case ActionSetTemperature:
-		if evt.Temperature != nil {
-			st.Temperature = *evt.Temperature
+	case ActionSetColor, ActionSetRGB, ActionSetTemperature:
+		if evt.Color != nil {
+			st.Color = evt.Color
 		}
 	case ActionSetScene:
 		if evt.Scene != nil {
@@ -213,12 +359,25 @@ func (s Store) SetReportedFromEvent(evt Event) error {
 	return s.writeEffective(st)
 }
 
-func (s Store) TurnOn() error              { return s.SetDesiredFromCommand(Command{Type: ActionTurnOn}) }
-func (s Store) TurnOff() error             { return s.SetDesiredFromCommand(Command{Type: ActionTurnOff}) }
-func (s Store) SetBrightness(v int) error  { return s.SetDesiredFromCommand(Command{Type: ActionSetBrightness, Brightness: &v}) }
-func (s Store) SetRGB(r, g, b int) error   { rgb := []int{r, g, b}; return s.SetDesiredFromCommand(Command{Type: ActionSetRGB, RGB: &rgb}) }
-func (s Store) SetTemperature(v int) error { return s.SetDesiredFromCommand(Command{Type: ActionSetTemperature, Temperature: &v}) }
-func (s Store) SetScene(scene string) error { return s.SetDesiredFromCommand(Command{Type: ActionSetScene, Scene: &scene}) }
+func (s Store) clampToGamut(c *color.Value) *color.Value {
+	if c == nil || s.gamut == "" {
+		return c
+	}
+	clamped := color.ClampToGamut(*c, s.gamut)
+	return &clamped
+}
+
+func (s Store) TurnOn() error  { return s.SetDesiredFromCommand(Command{Type: ActionTurnOn}) }
+func (s Store) TurnOff() error { return s.SetDesiredFromCommand(Command{Type: ActionTurnOff}) }
+func (s Store) SetBrightness(v int) error {
+	return s.SetDesiredFromCommand(Command{Type: ActionSetBrightness, Brightness: &v})
+}
+func (s Store) SetColor(c color.Value) error {
+	return s.SetDesiredFromCommand(Command{Type: ActionSetColor, Color: &c})
+}
+func (s Store) SetScene(scene string) error {
+	return s.SetDesiredFromCommand(Command{Type: ActionSetScene, Scene: &scene})
+}
 
 func decodeState(raw json.RawMessage) (State, error) {
 	if len(raw) == 0 {
@@ -254,9 +413,3 @@ func (s Store) writeEffective(st State) error {
 	s.entity.Data.Effective = b
 	return nil
 }
-
-func cloneInts(src []int) []int {
-	dst := make([]int, len(src))
-	copy(dst, src)
-	return dst
-}
@@ -0,0 +1,169 @@
+// Package registry indexes entities and resolves the reference selectors
+// (tag:, id:, name:, domain:, all) that scenes and automations use to target
+// many entities at once.
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slidebolt/sdk-entities/light"
+	"github.com/slidebolt/sdk-entities/sensor"
+	entityswitch "github.com/slidebolt/sdk-entities/switch"
+	"github.com/slidebolt/sdk-types"
+)
+
+// Registry holds every entity it has been given and resolves reference
+// selectors against them.
+type Registry struct {
+	entities []*types.Entity
+}
+
+func New() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Add(entity *types.Entity) {
+	r.entities = append(r.entities, entity)
+}
+
+// Fetch resolves ref to the entities it matches. A selector is one or more
+// comma-joined clauses, each of the form "tag:x", "id:x", "name:x",
+// "domain:light", or "all"; comma-joining clauses intersects them, e.g.
+// "tag:living,domain:light" matches light entities tagged "living".
+func (r *Registry) Fetch(ref string) ([]*types.Entity, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("registry: empty selector")
+	}
+
+	matched := r.entities
+	for _, clause := range strings.Split(ref, ",") {
+		var err error
+		matched, err = filterClause(clause, matched)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("registry: selector %q matched no entities", ref)
+	}
+	return matched, nil
+}
+
+func filterClause(clause string, entities []*types.Entity) ([]*types.Entity, error) {
+	if clause == "all" {
+		return entities, nil
+	}
+
+	kind, value, ok := strings.Cut(clause, ":")
+	if !ok {
+		return nil, fmt.Errorf("registry: invalid selector clause %q", clause)
+	}
+
+	var out []*types.Entity
+	for _, entity := range entities {
+		switch kind {
+		case "tag":
+			if hasTag(entity.Tags, value) {
+				out = append(out, entity)
+			}
+		case "id":
+			if entity.ID == value {
+				out = append(out, entity)
+			}
+		case "name":
+			if entity.Name == value {
+				out = append(out, entity)
+			}
+		case "domain":
+			if entity.Domain == value {
+				out = append(out, entity)
+			}
+		default:
+			return nil, fmt.Errorf("registry: unknown selector prefix %q", kind)
+		}
+	}
+	return out, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Bind resolves ref and returns the domain-specific Store for each matched
+// entity (light.Store, entityswitch.Store, or sensor.Store), in the same
+// order Fetch would return the entities.
+func (r *Registry) Bind(ref string) ([]any, error) {
+	entities, err := r.Fetch(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]any, 0, len(entities))
+	for _, entity := range entities {
+		store, err := bindStore(entity)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+	return stores, nil
+}
+
+func bindStore(entity *types.Entity) (any, error) {
+	switch entity.Domain {
+	case light.Type:
+		return light.Bind(entity), nil
+	case entityswitch.Type:
+		return entityswitch.Bind(entity), nil
+	case sensor.Type:
+		return sensor.Bind(entity), nil
+	default:
+		return nil, fmt.Errorf("registry: unknown domain %q for entity %s", entity.Domain, entity.ID)
+	}
+}
+
+// BroadcastCommand resolves ref and applies cmd to every matched entity via
+// its domain's SetDesiredFromCommand, decoding cmd.Payload per-domain along
+// the way. It keeps going on a per-entity failure and returns every error
+// keyed by entity ID, so one bad apple in a broadcast doesn't stop the rest.
+func (r *Registry) BroadcastCommand(ref string, cmd types.Command) (map[string]error, error) {
+	entities, err := r.Fetch(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := map[string]error{}
+	for _, entity := range entities {
+		if err := dispatchCommand(entity, cmd); err != nil {
+			errs[entity.ID] = err
+		}
+	}
+	return errs, nil
+}
+
+func dispatchCommand(entity *types.Entity, cmd types.Command) error {
+	switch entity.Domain {
+	case light.Type:
+		lc, err := light.ParseCommand(cmd)
+		if err != nil {
+			return err
+		}
+		return light.Bind(entity).SetDesiredFromCommand(lc)
+	case entityswitch.Type:
+		sc, err := entityswitch.ParseCommand(cmd)
+		if err != nil {
+			return err
+		}
+		return entityswitch.Bind(entity).SetDesiredFromCommand(sc)
+	case sensor.Type:
+		return fmt.Errorf("registry: entity %s is sensor domain, which is event-only and accepts no commands", entity.ID)
+	default:
+		return fmt.Errorf("registry: unknown domain %q for entity %s", entity.Domain, entity.ID)
+	}
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/slidebolt/sdk-entities/light"
+	entityswitch "github.com/slidebolt/sdk-entities/switch"
+	"github.com/slidebolt/sdk-types"
+)
+
+func newRegistry(entities ...*types.Entity) *Registry {
+	r := New()
+	for _, e := range entities {
+		r.Add(e)
+	}
+	return r
+}
+
+func TestFetchSingleClause(t *testing.T) {
+	lamp := &types.Entity{ID: "lamp1", Domain: light.Type, Tags: []string{"living"}}
+	sw := &types.Entity{ID: "sw1", Domain: entityswitch.Type, Tags: []string{"kitchen"}}
+	r := newRegistry(lamp, sw)
+
+	matched, err := r.Fetch("domain:light")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(matched) != 1 || matched[0] != lamp {
+		t.Fatalf("Fetch(domain:light) = %v, want [lamp1]", matched)
+	}
+}
+
+func TestFetchCommaJoinedIntersection(t *testing.T) {
+	livingLamp := &types.Entity{ID: "lamp1", Domain: light.Type, Tags: []string{"living"}}
+	kitchenLamp := &types.Entity{ID: "lamp2", Domain: light.Type, Tags: []string{"kitchen"}}
+	livingSwitch := &types.Entity{ID: "sw1", Domain: entityswitch.Type, Tags: []string{"living"}}
+	r := newRegistry(livingLamp, kitchenLamp, livingSwitch)
+
+	matched, err := r.Fetch("tag:living,domain:light")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(matched) != 1 || matched[0] != livingLamp {
+		t.Fatalf("Fetch(tag:living,domain:light) = %v, want [lamp1]", matched)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	lamp := &types.Entity{ID: "lamp1", Domain: light.Type}
+	sw := &types.Entity{ID: "sw1", Domain: entityswitch.Type}
+	r := newRegistry(lamp, sw)
+
+	matched, err := r.Fetch("all")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(matched) != 2 {
+		t.Fatalf("Fetch(all) matched %d entities, want 2", len(matched))
+	}
+}
+
+func TestFetchEmptySelector(t *testing.T) {
+	r := newRegistry(&types.Entity{ID: "lamp1", Domain: light.Type})
+	if _, err := r.Fetch(""); err == nil {
+		t.Fatal("expected error for an empty selector")
+	}
+}
+
+func TestFetchNoMatch(t *testing.T) {
+	r := newRegistry(&types.Entity{ID: "lamp1", Domain: light.Type})
+	if _, err := r.Fetch("tag:nonexistent"); err == nil {
+		t.Fatal("expected error when a selector matches no entities")
+	}
+}
+
+func TestFetchUnknownPrefix(t *testing.T) {
+	r := newRegistry(&types.Entity{ID: "lamp1", Domain: light.Type})
+	if _, err := r.Fetch("bogus:lamp1"); err == nil {
+		t.Fatal("expected error for an unknown selector prefix")
+	}
+}
+
+func TestFetchInvalidClause(t *testing.T) {
+	r := newRegistry(&types.Entity{ID: "lamp1", Domain: light.Type})
+	if _, err := r.Fetch("not-a-clause"); err == nil {
+		t.Fatal("expected error for a clause with no prefix")
+	}
+}
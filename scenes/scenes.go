@@ -0,0 +1,302 @@
+// Package scenes loads YAML scene definitions and applies them across many
+// entities at once, dispatching into each entity's domain-specific Store.
+package scenes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/slidebolt/sdk-entities/color"
+	"github.com/slidebolt/sdk-entities/light"
+	"github.com/slidebolt/sdk-entities/registry"
+	entityswitch "github.com/slidebolt/sdk-entities/switch"
+	"github.com/slidebolt/sdk-types"
+)
+
+// LightState is the subset of light.State a scene target or override may set.
+// Unset fields are left untouched on the target entity.
+type LightState struct {
+	Power       *bool `yaml:"power,omitempty"`
+	Brightness  *int  `yaml:"brightness,omitempty"`
+	RGB         []int `yaml:"rgb,omitempty"`
+	Temperature *int  `yaml:"temperature,omitempty"`
+}
+
+// SwitchState is the subset of entityswitch.State a scene target may set.
+type SwitchState struct {
+	Power *bool `yaml:"power,omitempty"`
+}
+
+// Target is one selector within a scene and the state it assigns to every
+// entity the selector matches.
+type Target struct {
+	Selector string       `yaml:"selector"`
+	Light    *LightState  `yaml:"light,omitempty"`
+	Switch   *SwitchState `yaml:"switch,omitempty"`
+}
+
+// Scene is a single named, multi-entity desired state, as loaded from a YAML
+// file such as evening.yaml.
+type Scene struct {
+	Name      string                `yaml:"name"`
+	Targets   []Target              `yaml:"targets"`
+	Overrides map[string]LightState `yaml:"overrides,omitempty"`
+}
+
+// Registry holds every scene loaded by Load, keyed by name.
+type Registry struct {
+	scenes map[string]Scene
+}
+
+// Load reads every *.yaml/*.yml file under fsys and returns a Registry of the
+// scenes it defines. A scene's name is taken from its "name" field, falling
+// back to the file's base name (without extension) when omitted.
+func Load(fsys fs.FS) (Registry, error) {
+	reg := Registry{scenes: map[string]Scene{}}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		raw, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("scenes: read %s: %w", path, err)
+		}
+
+		var scene Scene
+		if err := yaml.Unmarshal(raw, &scene); err != nil {
+			return fmt.Errorf("scenes: parse %s: %w", path, err)
+		}
+		if scene.Name == "" {
+			base := filepath.Base(path)
+			scene.Name = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		if _, exists := reg.scenes[scene.Name]; exists {
+			return fmt.Errorf("scenes: duplicate scene name %q (from %s)", scene.Name, path)
+		}
+		reg.scenes[scene.Name] = scene
+		return nil
+	})
+	if err != nil {
+		return Registry{}, err
+	}
+	return reg, nil
+}
+
+// Names returns every loaded scene name.
+func (r Registry) Names() []string {
+	names := make([]string, 0, len(r.scenes))
+	for name := range r.scenes {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Apply resolves the named scene against entities and returns the
+// per-domain types.Command produced for every entity the scene touched,
+// writing the resulting desired state into each matched entity's domain
+// Store along the way. It fails closed: every target entity's
+// Store.Supports for every action the scene would send it is checked across
+// the whole scene first, and Apply writes nothing to any entity unless the
+// entire scene validates. Selectors are resolved via registry, so scenes
+// support the full tag:/id:/name:/domain:/all grammar, including
+// comma-joined intersections.
+func (r Registry) Apply(name string, entities []*types.Entity) ([]types.Command, error) {
+	scene, ok := r.scenes[name]
+	if !ok {
+		return nil, fmt.Errorf("scenes: unknown scene %q", name)
+	}
+
+	reg := registry.New()
+	for _, entity := range entities {
+		reg.Add(entity)
+	}
+
+	var writes []pendingWrite
+	for _, target := range scene.Targets {
+		matched, err := reg.Fetch(target.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("scenes: scene %q: %w", name, err)
+		}
+
+		for _, entity := range matched {
+			state := target.Light
+			if override, ok := scene.Overrides[entity.ID]; ok && state != nil {
+				state = mergeLightState(*state, override)
+			}
+
+			switch {
+			case state != nil && entity.Domain == light.Type:
+				writes = append(writes, planLight(entity, *state))
+			case target.Switch != nil && entity.Domain == entityswitch.Type:
+				if w := planSwitch(entity, *target.Switch); w != nil {
+					writes = append(writes, w)
+				}
+			}
+		}
+	}
+
+	for _, w := range writes {
+		if err := w.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	var cmds []types.Command
+	for _, w := range writes {
+		produced, err := w.apply()
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, produced...)
+	}
+	return cmds, nil
+}
+
+func mergeLightState(base, override LightState) *LightState {
+	merged := base
+	if override.Power != nil {
+		merged.Power = override.Power
+	}
+	if override.Brightness != nil {
+		merged.Brightness = override.Brightness
+	}
+	if override.RGB != nil {
+		merged.RGB = override.RGB
+	}
+	if override.Temperature != nil {
+		merged.Temperature = override.Temperature
+	}
+	return &merged
+}
+
+// pendingWrite is one entity's planned domain writes for a scene
+// application. validate checks every planned action's Store.Supports
+// without touching the entity; apply performs the writes and reports the
+// resulting types.Command envelopes. Apply only calls apply after every
+// pendingWrite in the scene has validated, so a scene either writes
+// everything or mutates nothing.
+type pendingWrite interface {
+	validate() error
+	apply() ([]types.Command, error)
+}
+
+type lightWrite struct {
+	entity *types.Entity
+	store  light.Store
+	cmds   []light.Command
+}
+
+// planLight builds the light.Commands state implies, without binding them
+// to Store.Supports or writing anything yet.
+func planLight(entity *types.Entity, state LightState) *lightWrite {
+	w := &lightWrite{entity: entity, store: light.Bind(entity)}
+
+	if state.Power != nil {
+		action := light.ActionTurnOff
+		if *state.Power {
+			action = light.ActionTurnOn
+		}
+		w.cmds = append(w.cmds, light.Command{Type: action})
+	}
+	if state.Brightness != nil {
+		w.cmds = append(w.cmds, light.Command{Type: light.ActionSetBrightness, Brightness: state.Brightness})
+	}
+	if state.RGB != nil {
+		v := color.FromRGB(state.RGB[0], state.RGB[1], state.RGB[2])
+		w.cmds = append(w.cmds, light.Command{Type: light.ActionSetColor, Color: &v})
+	}
+	if state.Temperature != nil {
+		v := color.FromKelvin(*state.Temperature)
+		w.cmds = append(w.cmds, light.Command{Type: light.ActionSetColor, Color: &v})
+	}
+	return w
+}
+
+func (w *lightWrite) validate() error {
+	for _, cmd := range w.cmds {
+		if !w.store.Supports(cmd.Type) {
+			return fmt.Errorf("scenes: entity %s does not support %s", w.entity.ID, cmd.Type)
+		}
+	}
+	return nil
+}
+
+func (w *lightWrite) apply() ([]types.Command, error) {
+	cmds := make([]types.Command, 0, len(w.cmds))
+	for _, cmd := range w.cmds {
+		if err := w.store.SetDesiredFromCommand(cmd); err != nil {
+			return nil, err
+		}
+		payload, err := json.Marshal(cmd)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, types.Command{EntityID: w.entity.ID, Domain: light.Type, Payload: payload})
+	}
+	return cmds, nil
+}
+
+type switchWrite struct {
+	entity *types.Entity
+	store  entityswitch.Store
+	cmd    entityswitch.Command
+}
+
+// planSwitch returns nil when state has nothing to apply.
+func planSwitch(entity *types.Entity, state SwitchState) *switchWrite {
+	if state.Power == nil {
+		return nil
+	}
+	action := entityswitch.ActionTurnOff
+	if *state.Power {
+		action = entityswitch.ActionTurnOn
+	}
+	return &switchWrite{entity: entity, store: entityswitch.Bind(entity), cmd: entityswitch.Command{Type: action}}
+}
+
+func (w *switchWrite) validate() error {
+	if !w.store.Supports(w.cmd.Type) {
+		return fmt.Errorf("scenes: entity %s does not support %s", w.entity.ID, w.cmd.Type)
+	}
+	return nil
+}
+
+func (w *switchWrite) apply() ([]types.Command, error) {
+	if err := w.store.SetDesiredFromCommand(w.cmd); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(w.cmd)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Command{{EntityID: w.entity.ID, Domain: entityswitch.Type, Payload: payload}}, nil
+}
+
+// Describe returns a types.DomainDescriptor listing every loaded scene as an
+// action, so scenes can be surfaced through the same discovery mechanism as
+// per-entity domains. Callers decide when to call types.RegisterDomain with
+// the result, since scenes are loaded at runtime rather than at package init.
+func (r Registry) Describe() types.DomainDescriptor {
+	actions := make([]types.ActionDescriptor, 0, len(r.scenes))
+	for _, name := range r.Names() {
+		actions = append(actions, types.ActionDescriptor{Action: name})
+	}
+	return types.DomainDescriptor{
+		Domain:   "scene",
+		Commands: actions,
+	}
+}
@@ -0,0 +1,92 @@
+package scenes
+
+import (
+	"testing"
+
+	"github.com/slidebolt/sdk-entities/light"
+	"github.com/slidebolt/sdk-types"
+)
+
+func newLightEntity(id string, actions []string) *types.Entity {
+	return &types.Entity{
+		ID:      id,
+		Domain:  light.Type,
+		Tags:    []string{"living"},
+		Actions: actions,
+	}
+}
+
+func TestApplyFailsClosedWithoutMutatingEntities(t *testing.T) {
+	entity := newLightEntity("lamp1", []string{light.ActionTurnOn, light.ActionTurnOff, light.ActionSetBrightness})
+
+	power := true
+	brightness := 50
+	reg := Registry{scenes: map[string]Scene{
+		"evening": {
+			Name: "evening",
+			Targets: []Target{{
+				Selector: "id:lamp1",
+				Light: &LightState{
+					Power:      &power,
+					Brightness: &brightness,
+					RGB:        []int{255, 0, 0}, // lamp1 doesn't support set_color
+				},
+			}},
+		},
+	}}
+
+	_, err := reg.Apply("evening", []*types.Entity{entity})
+	if err == nil {
+		t.Fatal("expected Apply to fail for an unsupported action")
+	}
+
+	st, decodeErr := light.Bind(entity).Desired()
+	if decodeErr != nil {
+		t.Fatalf("Desired: %v", decodeErr)
+	}
+	if st.Power || st.Brightness != 0 {
+		t.Fatalf("Apply mutated Desired before failing: %+v, want untouched entity", st)
+	}
+}
+
+func TestApplyWritesEveryTargetWhenAllSupported(t *testing.T) {
+	entity := newLightEntity("lamp1", light.SupportedActions())
+
+	power := true
+	brightness := 50
+	reg := Registry{scenes: map[string]Scene{
+		"evening": {
+			Name: "evening",
+			Targets: []Target{{
+				Selector: "id:lamp1",
+				Light: &LightState{
+					Power:      &power,
+					Brightness: &brightness,
+				},
+			}},
+		},
+	}}
+
+	cmds, err := reg.Apply("evening", []*types.Entity{entity})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("len(cmds) = %d, want 2", len(cmds))
+	}
+
+	st, err := light.Bind(entity).Desired()
+	if err != nil {
+		t.Fatalf("Desired: %v", err)
+	}
+	if !st.Power || st.Brightness != 50 {
+		t.Fatalf("Desired = %+v, want Power=true Brightness=50", st)
+	}
+}
+
+func TestApplyUnknownScene(t *testing.T) {
+	reg := Registry{scenes: map[string]Scene{}}
+	if _, err := reg.Apply("missing", nil); err == nil {
+		t.Fatal("expected error for an unknown scene name")
+	}
+}
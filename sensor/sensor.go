@@ -0,0 +1,236 @@
+package sensor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/slidebolt/sdk-types"
+)
+
+const Type = "sensor"
+
+// SubType distinguishes what a sensor entity reports; it does not change the
+// domain's wire shape, only which Event fields and actions are expected to
+// be populated.
+const (
+	SubTypeMotion      = "motion"
+	SubTypeContact     = "contact"
+	SubTypeButton      = "button"
+	SubTypeIlluminance = "illuminance"
+	SubTypeTemperature = "temperature"
+)
+
+const (
+	ActionMotion      = "motion"
+	ActionContact     = "contact"
+	ActionButton      = "button"
+	ActionIlluminance = "illuminance"
+	ActionTemperature = "temperature"
+)
+
+const (
+	ButtonActionShortPress = "short_press"
+	ButtonActionLongPress  = "long_press"
+	ButtonActionHold       = "hold"
+	ButtonActionRelease    = "release"
+)
+
+// Button describes a single button-press event.
+type Button struct {
+	ID     int    `json:"id"`
+	Action string `json:"action"`
+}
+
+// State is the last-reported reading for a sensor entity, plus when it was
+// last triggered. Only the fields relevant to the entity's SubType are set.
+type State struct {
+	SubType       string     `json:"sub_type,omitempty"`
+	Motion        *bool      `json:"motion,omitempty"`
+	Contact       *bool      `json:"contact,omitempty"`
+	Button        *Button    `json:"button,omitempty"`
+	Lux           *int       `json:"lux,omitempty"`
+	Temperature   *float64   `json:"temperature,omitempty"`
+	LastTriggered *time.Time `json:"last_triggered,omitempty"`
+}
+
+// Command exists only so sensor satisfies the same ParseCommand/ValidateCommand
+// shape as the other domains; sensors are event-only and every command is
+// rejected by ValidateCommand.
+type Command struct {
+	Type string `json:"type"`
+}
+
+// Event carries a single sensor reading.
+type Event struct {
+	Type             string   `json:"type"`
+	Motion           *bool    `json:"motion,omitempty"`
+	Contact          *bool    `json:"contact,omitempty"`
+	Button           *Button  `json:"button,omitempty"`
+	Lux              *int     `json:"lux,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	AvailableActions []string `json:"available_actions,omitempty"`
+	Cause            string   `json:"cause,omitempty"`
+}
+
+func init() {
+	types.RegisterDomain(Describe())
+}
+
+func Describe() types.DomainDescriptor {
+	motion := []types.FieldDescriptor{{Name: "motion", Type: "bool", Required: true}}
+	contact := []types.FieldDescriptor{{Name: "contact", Type: "bool", Required: true}}
+	button := []types.FieldDescriptor{
+		{Name: "button.id", Type: "int", Required: true},
+		{Name: "button.action", Type: "string", Required: true},
+	}
+	lux := []types.FieldDescriptor{{Name: "lux", Type: "int", Required: true, Min: intPtr(0)}}
+	temperature := []types.FieldDescriptor{{Name: "temperature", Type: "float64", Required: true}}
+
+	events := []types.ActionDescriptor{
+		{Action: ActionMotion, Fields: motion},
+		{Action: ActionContact, Fields: contact},
+		{Action: ActionButton, Fields: button},
+		{Action: ActionIlluminance, Fields: lux},
+		{Action: ActionTemperature, Fields: temperature},
+	}
+
+	return types.DomainDescriptor{
+		Domain: Type,
+		Events: events,
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+// SupportedActions returns the event types a sensor of the given sub-type
+// can emit.
+func SupportedActions(subType string) []string {
+	switch subType {
+	case SubTypeMotion:
+		return []string{ActionMotion}
+	case SubTypeContact:
+		return []string{ActionContact}
+	case SubTypeButton:
+		return []string{ActionButton}
+	case SubTypeIlluminance:
+		return []string{ActionIlluminance}
+	case SubTypeTemperature:
+		return []string{ActionTemperature}
+	default:
+		return nil
+	}
+}
+
+func ParseCommand(cmd types.Command) (Command, error) {
+	var c Command
+	if err := json.Unmarshal(cmd.Payload, &c); err != nil {
+		return c, err
+	}
+	return c, ValidateCommand(c)
+}
+
+func ParseEvent(evt types.Event) (Event, error) {
+	var e Event
+	if err := json.Unmarshal(evt.Payload, &e); err != nil {
+		return e, err
+	}
+	return e, ValidateEvent(e)
+}
+
+// ValidateCommand always fails: sensors are event-only and accept no commands.
+func ValidateCommand(c Command) error {
+	return fmt.Errorf("sensor domain is event-only: %q is not a valid command", c.Type)
+}
+
+func ValidateEvent(e Event) error {
+	switch e.Type {
+	case ActionMotion, ActionContact, ActionButton, ActionIlluminance, ActionTemperature:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sensor event: %s", e.Type)
+	}
+}
+
+// Store binds to an Entity and manages reported/effective sensor state.
+// Sensors have no Desired state: there is nothing to command.
+type Store struct {
+	entity *types.Entity
+}
+
+func Bind(entity *types.Entity) Store {
+	return Store{entity: entity}
+}
+
+func (s Store) EnsureDefaultActions(subType string) {
+	if len(s.entity.Actions) == 0 {
+		s.entity.Actions = SupportedActions(subType)
+	}
+}
+
+func (s Store) Supports(action string) bool {
+	for _, a := range s.entity.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Store) Reported() (State, error) { return decodeState(s.entity.Data.Reported) }
+
+// SetReportedFromEvent records evt as the entity's last-reported state,
+// stamps LastTriggered with now, and mirrors the result into Effective since
+// a sensor's effective state is always whatever it last reported.
+func (s Store) SetReportedFromEvent(evt Event, now time.Time) error {
+	st, _ := s.Reported()
+	switch evt.Type {
+	case ActionMotion:
+		st.SubType = SubTypeMotion
+		st.Motion = evt.Motion
+	case ActionContact:
+		st.SubType = SubTypeContact
+		st.Contact = evt.Contact
+	case ActionButton:
+		st.SubType = SubTypeButton
+		st.Button = evt.Button
+	case ActionIlluminance:
+		st.SubType = SubTypeIlluminance
+		st.Lux = evt.Lux
+	case ActionTemperature:
+		st.SubType = SubTypeTemperature
+		st.Temperature = evt.Temperature
+	}
+	st.LastTriggered = &now
+
+	if err := s.writeReported(st); err != nil {
+		return err
+	}
+	return s.writeEffective(st)
+}
+
+func decodeState(raw json.RawMessage) (State, error) {
+	if len(raw) == 0 {
+		return State{}, nil
+	}
+	var st State
+	return st, json.Unmarshal(raw, &st)
+}
+
+func (s Store) writeReported(st State) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	s.entity.Data.Reported = b
+	return nil
+}
+
+func (s Store) writeEffective(st State) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	s.entity.Data.Effective = b
+	return nil
+}
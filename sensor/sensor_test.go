@@ -0,0 +1,76 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slidebolt/sdk-types"
+)
+
+func TestSetReportedFromEventMotion(t *testing.T) {
+	entity := &types.Entity{ID: "motion1", Domain: Type}
+	store := Bind(entity)
+
+	motion := true
+	now := time.Unix(1700000000, 0)
+	if err := store.SetReportedFromEvent(Event{Type: ActionMotion, Motion: &motion}, now); err != nil {
+		t.Fatalf("SetReportedFromEvent: %v", err)
+	}
+
+	st, err := store.Reported()
+	if err != nil {
+		t.Fatalf("Reported: %v", err)
+	}
+	if st.SubType != SubTypeMotion || st.Motion == nil || !*st.Motion {
+		t.Fatalf("Reported = %+v, want SubType=motion Motion=true", st)
+	}
+	if st.LastTriggered == nil || !st.LastTriggered.Equal(now) {
+		t.Fatalf("LastTriggered = %v, want %v", st.LastTriggered, now)
+	}
+
+	effective, err := decodeState(entity.Data.Effective)
+	if err != nil {
+		t.Fatalf("decodeState(Effective): %v", err)
+	}
+	if effective.Motion == nil || !*effective.Motion {
+		t.Fatalf("Effective = %+v, want Motion=true mirrored from Reported", effective)
+	}
+}
+
+func TestValidateCommandAlwaysRejected(t *testing.T) {
+	if err := ValidateCommand(Command{Type: ActionMotion}); err == nil {
+		t.Fatal("expected ValidateCommand to reject every command: sensors are event-only")
+	}
+}
+
+func TestSupportedActionsBySubType(t *testing.T) {
+	cases := []struct {
+		subType string
+		want    []string
+	}{
+		{SubTypeMotion, []string{ActionMotion}},
+		{SubTypeButton, []string{ActionButton}},
+		{"unknown", nil},
+	}
+	for _, tc := range cases {
+		got := SupportedActions(tc.subType)
+		if len(got) != len(tc.want) {
+			t.Fatalf("SupportedActions(%q) = %v, want %v", tc.subType, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("SupportedActions(%q) = %v, want %v", tc.subType, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestEnsureDefaultActionsDoesNotOverwriteExisting(t *testing.T) {
+	entity := &types.Entity{ID: "motion1", Domain: Type, Actions: []string{ActionContact}}
+	store := Bind(entity)
+	store.EnsureDefaultActions(SubTypeMotion)
+
+	if len(entity.Actions) != 1 || entity.Actions[0] != ActionContact {
+		t.Fatalf("Actions = %v, want unchanged [%s]", entity.Actions, ActionContact)
+	}
+}